@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	"aig/pkg/plugins"
+
+	"github.com/spf13/cobra"
+)
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage layer plugins",
+}
+
+var pluginLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List installed layer plugins",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		infos, err := plugins.Discover()
+		if err != nil {
+			return err
+		}
+		if len(infos) == 0 {
+			fmt.Println("No plugins installed")
+			return nil
+		}
+		for _, info := range infos {
+			fmt.Printf("%s\t%s\n", info.Name, info.Path)
+		}
+		return nil
+	},
+}
+
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install <path>",
+	Short: "Install a layer plugin binary",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return plugins.Install(args[0])
+	},
+}
+
+var pluginRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove an installed layer plugin",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return plugins.Remove(args[0])
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginLsCmd, pluginInstallCmd, pluginRmCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
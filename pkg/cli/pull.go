@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"aig/pkg/docker"
+	"aig/pkg/ipfs"
+
+	"github.com/spf13/cobra"
+)
+
+var pullIPFS bool
+
+var pullCmd = &cobra.Command{
+	Use:   "pull <cid>",
+	Short: "Pull an image from a distribution backend",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !pullIPFS {
+			return fmt.Errorf("pull requires a backend flag, e.g. --ipfs")
+		}
+
+		builder, err := docker.NewBuilder()
+		if err != nil {
+			return err
+		}
+
+		client := ipfs.NewClient(ipfsAPIAddr)
+		imageName, err := builder.PullFromIPFS(context.Background(), client, args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Pulled %s from IPFS\n", imageName)
+		return nil
+	},
+}
+
+func init() {
+	pullCmd.Flags().BoolVar(&pullIPFS, "ipfs", false, "Pull from an IPFS node instead of a registry")
+	pullCmd.Flags().StringVar(&ipfsAPIAddr, "ipfs-api", "localhost:5001", "IPFS API address")
+
+	rootCmd.AddCommand(pullCmd)
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"aig/pkg/docker"
+	"aig/pkg/ipfs"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushIPFS    bool
+	ipfsAPIAddr string
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push <image>",
+	Short: "Push a built image to a distribution backend",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !pushIPFS {
+			return fmt.Errorf("push requires a backend flag, e.g. --ipfs")
+		}
+
+		builder, err := docker.NewBuilder()
+		if err != nil {
+			return err
+		}
+
+		client := ipfs.NewClient(ipfsAPIAddr)
+		cid, err := builder.PushToIPFS(context.Background(), client, args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Pushed %s to IPFS: %s\n", args[0], cid)
+		return nil
+	},
+}
+
+func init() {
+	pushCmd.Flags().BoolVar(&pushIPFS, "ipfs", false, "Push to an IPFS node instead of a registry")
+	pushCmd.Flags().StringVar(&ipfsAPIAddr, "ipfs-api", "localhost:5001", "IPFS API address")
+
+	rootCmd.AddCommand(pushCmd)
+}
@@ -6,6 +6,8 @@ import (
 
 	"aig/pkg/docker"
 	"aig/pkg/layers"
+	"aig/pkg/layers/config"
+	"aig/pkg/plugins"
 
 	"github.com/spf13/cobra"
 )
@@ -16,50 +18,109 @@ var (
 	topLayerName string
 	volumes      []string
 	ports        []string
+	cacheFrom    []string
+	squash       bool
+	composeFile  string
 )
 
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Build and run a customized container",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		image, layerList, topName, vols, prts, env, err := resolveRunConfig(cmd)
+		if err != nil {
+			return err
+		}
+
 		builder, err := docker.NewBuilder()
 		if err != nil {
 			return err
 		}
 
 		base := &layers.BaseLayer{
-			Image:   baseImage,
-			Volumes: volumes,
-			Ports:   ports,
+			Image:   image,
+			Volumes: vols,
+			Ports:   prts,
 		}
-		
+
 		var selectedLayers []layers.Layer
-		for _, name := range layerNames {
-			l, err := layers.Get(strings.TrimSpace(name))
+		for _, name := range layerList {
+			l, err := getLayer(strings.TrimSpace(name))
 			if err != nil {
 				return err
 			}
 			selectedLayers = append(selectedLayers, l)
 		}
 
-		if topLayerName != "" {
-			top, err := layers.Get(topLayerName)
+		if topName != "" {
+			top, err := getLayer(topName)
 			if err != nil {
 				return err
 			}
 			selectedLayers = append(selectedLayers, top)
 		}
 
-		return builder.BuildAndRun(context.Background(), base, selectedLayers)
+		return builder.BuildAndRun(context.Background(), base, selectedLayers, docker.BuildOptions{
+			CacheFrom: cacheFrom,
+			Squash:    squash,
+			Env:       env,
+		})
 	},
 }
 
+// getLayer resolves name against the static layers registry first, falling
+// back to the installed plugins only if no static layer offers it, so a
+// plugin process is only ever spawned for a layer that's actually selected.
+func getLayer(name string) (layers.Layer, error) {
+	if l, err := layers.Get(name); err == nil {
+		return l, nil
+	}
+	return plugins.Resolve(name)
+}
+
+// resolveRunConfig builds the final base image, layer list, top layer, and
+// bind/port lists for `aig run`. With no -f/--file flag it's just the raw
+// CLI flags. With -f, the aig.yaml compose file provides the base
+// configuration: --base/--top explicitly set on the command line override
+// the file's values, while --layers/--volume/--port are appended to the
+// file's lists.
+func resolveRunConfig(cmd *cobra.Command) (image string, layerList []string, top string, vols []string, prts []string, env []string, err error) {
+	if composeFile == "" {
+		return baseImage, layerNames, topLayerName, volumes, ports, nil, nil
+	}
+
+	cf, err := config.LoadCompose(composeFile)
+	if err != nil {
+		return "", nil, "", nil, nil, nil, err
+	}
+
+	image = cf.Base.Image
+	if cmd.Flags().Changed("base") {
+		image = baseImage
+	}
+
+	top = cf.Top
+	if cmd.Flags().Changed("top") {
+		top = topLayerName
+	}
+
+	layerList = append(append([]string{}, cf.Layers...), layerNames...)
+	vols = append(append([]string{}, cf.Volumes...), volumes...)
+	prts = append(append([]string{}, cf.Ports...), ports...)
+	env = cf.Env
+
+	return image, layerList, top, vols, prts, env, nil
+}
+
 func init() {
 	runCmd.Flags().StringVarP(&baseImage, "base", "b", "ubuntu:22.04", "Base docker image")
 	runCmd.Flags().StringSliceVarP(&layerNames, "layers", "l", []string{}, "Comma-separated list of layers to include")
 	runCmd.Flags().StringVarP(&topLayerName, "top", "t", "", "Top layer (binary layer)")
 	runCmd.Flags().StringSliceVarP(&volumes, "volume", "v", []string{}, "Bind mount a volume (e.g. /host:/container)")
-	runCmd.Flags().StringSliceVarP(&ports, "port", "p", []string{}, "Publish a container's port(s) to the host (e.g. 8080:80)")
-	
+	runCmd.Flags().StringSliceVarP(&ports, "port", "p", []string{}, "Publish a container's port(s) to the host, Docker -p syntax (e.g. 8080:80, 53:53/udp, 127.0.0.1:8000-8005:8000-8005/tcp)")
+	runCmd.Flags().StringSliceVar(&cacheFrom, "cache-from", []string{}, "Images to use as build cache sources (pre-pulled before the build)")
+	runCmd.Flags().BoolVar(&squash, "squash", false, "Squash all RUN layers into a single filesystem diff on top of the FROM image")
+	runCmd.Flags().StringVarP(&composeFile, "file", "f", "", "Load run configuration from a YAML/JSON aig.yaml compose file; CLI flags override or append to it")
+
 	rootCmd.AddCommand(runCmd)
 }
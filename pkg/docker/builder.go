@@ -5,19 +5,45 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"aig/pkg/ipfs"
 	"aig/pkg/layers"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
 )
 
+// validVolumeOptions is the set of mount options accepted on the trailing
+// `:opts` segment of a bind spec, mirroring what `docker run -v` accepts.
+var validVolumeOptions = map[string]bool{
+	"ro": true, "rw": true,
+	"z": true, "Z": true,
+	"shared": true, "slave": true, "private": true,
+	"rshared": true, "rslave": true, "rprivate": true,
+	"nocopy": true,
+}
+
+// propagationModes maps the propagation mount options to their mount.Propagation
+// constant. Of the options not listed here, ro, rw, z and Z don't require a
+// mount.Mount and can be normalized back into a Binds string; nocopy is
+// promoted to a mount.Mount by parseVolumeSpecs.
+var propagationModes = map[string]mount.Propagation{
+	"shared":   mount.PropagationShared,
+	"slave":    mount.PropagationSlave,
+	"private":  mount.PropagationPrivate,
+	"rshared":  mount.PropagationRShared,
+	"rslave":   mount.PropagationRSlave,
+	"rprivate": mount.PropagationRPrivate,
+}
+
 type Builder struct {
 	cli *client.Client
 }
@@ -30,12 +56,22 @@ func NewBuilder() (*Builder, error) {
 	return &Builder{cli: cli}, nil
 }
 
-func (b *Builder) BuildAndRun(ctx context.Context, base layers.Layer, selectedLayers []layers.Layer) error {
+// BuildOptions controls how BuildAndRun builds and runs the composed image.
+type BuildOptions struct {
+	// CacheFrom lists images to pre-pull and pass as build cache sources.
+	CacheFrom []string
+	// Squash collapses all RUN layers into a single filesystem diff.
+	Squash bool
+	// Env lists "KEY=VALUE" environment variables set on the container.
+	Env []string
+}
+
+func (b *Builder) BuildAndRun(ctx context.Context, base layers.Layer, selectedLayers []layers.Layer, opts BuildOptions) error {
 	// Generate Dockerfile
 	dockerfile := b.generateDockerfile(base, selectedLayers)
-	
+
 	// Calculate Hash
-	tag := b.calculateTag(base, selectedLayers)
+	tag := b.calculateTag(base, selectedLayers, opts.Squash)
 	imageName := fmt.Sprintf("aig-image:%s", tag)
 
 	// Collect volumes and ports
@@ -57,8 +93,15 @@ func (b *Builder) BuildAndRun(ctx context.Context, base layers.Layer, selectedLa
 	}
 
 	if !exists {
+		if len(opts.CacheFrom) > 0 {
+			fmt.Printf("Pulling cache images: %s\n", strings.Join(opts.CacheFrom, ", "))
+			if err := b.PullCacheImages(ctx, opts.CacheFrom); err != nil {
+				return err
+			}
+		}
+
 		fmt.Printf("Building image %s...\n", imageName)
-		if err := b.buildImage(ctx, dockerfile, imageName); err != nil {
+		if err := b.buildImage(ctx, dockerfile, imageName, opts); err != nil {
 			return err
 		}
 	} else {
@@ -66,7 +109,83 @@ func (b *Builder) BuildAndRun(ctx context.Context, base layers.Layer, selectedLa
 	}
 
 	// Run container
-	return b.runContainer(ctx, imageName, volumes, ports)
+	return b.runContainer(ctx, imageName, volumes, ports, opts.Env)
+}
+
+// PullCacheImages pulls each of refs so the daemon has their manifests
+// locally before they're used as ImageBuildOptions.CacheFrom sources.
+func (b *Builder) PullCacheImages(ctx context.Context, refs []string) error {
+	for _, ref := range refs {
+		reader, err := b.cli.ImagePull(ctx, ref, types.ImagePullOptions{})
+		if err != nil {
+			return fmt.Errorf("pulling cache image %q: %w", ref, err)
+		}
+		_, err = io.Copy(io.Discard, reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("pulling cache image %q: %w", ref, err)
+		}
+	}
+	return nil
+}
+
+// PushToIPFS saves imageName as a `docker save` tar and adds it to IPFS,
+// returning the CID of the uploaded archive.
+func (b *Builder) PushToIPFS(ctx context.Context, ipfsClient *ipfs.Client, imageName string) (string, error) {
+	reader, err := b.cli.ImageSave(ctx, []string{imageName})
+	if err != nil {
+		return "", fmt.Errorf("saving image %q: %w", imageName, err)
+	}
+	defer reader.Close()
+
+	cid, err := ipfsClient.Add(ctx, reader)
+	if err != nil {
+		return "", err
+	}
+	return cid, nil
+}
+
+// PullFromIPFS fetches the `docker save` tar addressed by cid and loads it
+// into the daemon, returning the name of the image that was loaded.
+func (b *Builder) PullFromIPFS(ctx context.Context, ipfsClient *ipfs.Client, cid string) (string, error) {
+	rc, err := ipfsClient.Cat(ctx, cid)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	resp, err := b.cli.ImageLoad(ctx, rc, true)
+	if err != nil {
+		return "", fmt.Errorf("loading image from IPFS cid %s: %w", cid, err)
+	}
+	defer resp.Body.Close()
+
+	imageName, err := parseLoadedImageName(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("loading image from IPFS cid %s: %w", cid, err)
+	}
+	return imageName, nil
+}
+
+// parseLoadedImageName scans the ImageLoad response stream for Docker's
+// "Loaded image: <name>" progress line.
+func parseLoadedImageName(r io.Reader) (string, error) {
+	dec := json.NewDecoder(r)
+	for {
+		var msg struct {
+			Stream string `json:"stream"`
+		}
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", err
+		}
+		if name, ok := strings.CutPrefix(strings.TrimSpace(msg.Stream), "Loaded image: "); ok {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine image name from load response")
 }
 
 func (b *Builder) generateDockerfile(base layers.Layer, selected []layers.Layer) string {
@@ -82,12 +201,15 @@ func (b *Builder) generateDockerfile(base layers.Layer, selected []layers.Layer)
 	return sb.String()
 }
 
-func (b *Builder) calculateTag(base layers.Layer, selected []layers.Layer) string {
+func (b *Builder) calculateTag(base layers.Layer, selected []layers.Layer, squash bool) string {
 	h := sha256.New()
 	h.Write([]byte(base.GetHash()))
 	for _, l := range selected {
 		h.Write([]byte(l.GetHash()))
 	}
+	if squash {
+		h.Write([]byte("squash"))
+	}
 	return fmt.Sprintf("%x", h.Sum(nil))[:12]
 }
 
@@ -102,7 +224,7 @@ func (b *Builder) imageExists(ctx context.Context, name string) (bool, error) {
 	return true, nil
 }
 
-func (b *Builder) buildImage(ctx context.Context, dockerfile, tag string) error {
+func (b *Builder) buildImage(ctx context.Context, dockerfile, tag string, opts BuildOptions) error {
 	// Create build context (tar)
 	buf := new(bytes.Buffer)
 	tw := tar.NewWriter(buf)
@@ -126,6 +248,8 @@ func (b *Builder) buildImage(ctx context.Context, dockerfile, tag string) error
 		Dockerfile: "Dockerfile",
 		Tags:       []string{tag},
 		Remove:     true,
+		CacheFrom:  opts.CacheFrom,
+		Squash:     opts.Squash,
 	}
 
 	resp, err := b.cli.ImageBuild(ctx, buf, options)
@@ -139,43 +263,25 @@ func (b *Builder) buildImage(ctx context.Context, dockerfile, tag string) error
 	return err
 }
 
-func (b *Builder) runContainer(ctx context.Context, imageName string, volumes []string, ports []string) error {
-	exposedPorts := make(nat.PortSet)
-	portBindings := make(nat.PortMap)
-
-	for _, p := range ports {
-		parts := strings.Split(p, ":")
-		var hostPort, containerPort string
-		if len(parts) == 2 {
-			hostPort = parts[0]
-			containerPort = parts[1]
-		} else {
-			containerPort = parts[0]
-		}
-
-		if !strings.Contains(containerPort, "/") {
-			containerPort = containerPort + "/tcp"
-		}
-
-		cPort := nat.Port(containerPort)
-		exposedPorts[cPort] = struct{}{}
+func (b *Builder) runContainer(ctx context.Context, imageName string, volumes []string, ports []string, env []string) error {
+	exposedPorts, portBindings, err := parsePortSpecs(ports)
+	if err != nil {
+		return err
+	}
 
-		if hostPort != "" {
-			portBindings[cPort] = []nat.PortBinding{
-				{
-					HostIP:   "0.0.0.0",
-					HostPort: hostPort,
-				},
-			}
-		}
+	binds, mounts, err := parseVolumeSpecs(volumes)
+	if err != nil {
+		return err
 	}
 
 	resp, err := b.cli.ContainerCreate(ctx, &container.Config{
 		Image:        imageName,
 		Tty:          true,
+		Env:          env,
 		ExposedPorts: exposedPorts,
 	}, &container.HostConfig{
-		Binds:        volumes,
+		Binds:        binds,
+		Mounts:       mounts,
 		PortBindings: portBindings,
 	}, nil, nil, "")
 	if err != nil {
@@ -208,3 +314,141 @@ func (b *Builder) runContainer(ctx context.Context, imageName string, volumes []
 
 	return nil
 }
+
+// parsePortSpecs turns the raw `-p/--port` values into the exposed-port set
+// and host bindings ContainerCreate expects. Each entry follows the full
+// Docker `-p` syntax: containerPort[/proto], hostPort:containerPort[/proto],
+// ip:hostPort:containerPort[/proto], with ranges such as 8000-8005:8000-8005
+// on either side expanded into individual port mappings.
+func parsePortSpecs(ports []string) (nat.PortSet, nat.PortMap, error) {
+	exposedPorts := make(nat.PortSet)
+	portBindings := make(nat.PortMap)
+
+	for _, p := range ports {
+		mappings, err := nat.ParsePortSpec(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid port spec %q: %w", p, err)
+		}
+
+		for _, m := range mappings {
+			exposedPorts[m.Port] = struct{}{}
+
+			if m.Binding.HostPort == "" && m.Binding.HostIP == "" {
+				continue
+			}
+
+			binding := m.Binding
+			if binding.HostIP == "" {
+				binding.HostIP = "0.0.0.0"
+			}
+			portBindings[m.Port] = append(portBindings[m.Port], binding)
+		}
+	}
+
+	return exposedPorts, portBindings, nil
+}
+
+// parseVolumeSpecs splits each `host:container[:opts]` bind spec into its
+// mount options, validating them against the set Docker accepts (ro, rw,
+// SELinux z/Z, propagation modes, nocopy). Specs whose options are plain
+// Binds syntax are normalized and returned as Binds strings; specs that need
+// a propagation mode or nocopy are promoted to a mount.Mount, since
+// HostConfig.Binds can't express either.
+func parseVolumeSpecs(volumes []string) ([]string, []mount.Mount, error) {
+	var binds []string
+	var mounts []mount.Mount
+
+	for _, v := range volumes {
+		parts := strings.SplitN(v, ":", 3)
+		if len(parts) < 2 {
+			return nil, nil, fmt.Errorf("invalid volume spec %q: expected host:container[:opts]", v)
+		}
+		host, containerPath := parts[0], parts[1]
+
+		var opts []string
+		if len(parts) == 3 {
+			opts = strings.Split(parts[2], ",")
+		}
+
+		readOnly := false
+		propagation := mount.Propagation("")
+		nocopy := false
+		selinuxLabel := ""
+
+		for _, opt := range opts {
+			if !validVolumeOptions[opt] {
+				return nil, nil, fmt.Errorf("invalid volume spec %q: unknown mount option %q", v, opt)
+			}
+			switch opt {
+			case "ro":
+				readOnly = true
+			case "rw":
+				readOnly = false
+			case "nocopy":
+				nocopy = true
+			case "z", "Z":
+				if selinuxLabel != "" && selinuxLabel != opt {
+					return nil, nil, fmt.Errorf("invalid volume spec %q: conflicting SELinux options %q and %q", v, selinuxLabel, opt)
+				}
+				selinuxLabel = opt
+			default:
+				if p, ok := propagationModes[opt]; ok {
+					propagation = p
+				}
+			}
+		}
+
+		if propagation == "" && !nocopy {
+			// Plain Binds syntax already understands ro/rw/z/Z verbatim.
+			bind := host + ":" + containerPath
+			if len(opts) > 0 {
+				bind += ":" + strings.Join(opts, ",")
+			}
+			binds = append(binds, bind)
+			continue
+		}
+
+		// Neither mount.VolumeOptions nor mount.BindOptions has an SELinux
+		// relabel field, and there's no equivalent of the Binds string
+		// syntax's trailing ":z"/":Z" for mount.Mount.Source (it's taken
+		// literally as the volume name or host path) -- so a spec that
+		// needs promoting to mount.Mount for propagation/nocopy can't also
+		// carry an SELinux label.
+		if selinuxLabel != "" {
+			return nil, nil, fmt.Errorf("invalid volume spec %q: SELinux option %q cannot be combined with nocopy or a propagation mode", v, selinuxLabel)
+		}
+
+		if nocopy {
+			if isBindSource(host) {
+				return nil, nil, fmt.Errorf("invalid volume spec %q: nocopy only applies to named volumes, not bind mounts", v)
+			}
+			mounts = append(mounts, mount.Mount{
+				Type:          mount.TypeVolume,
+				Source:        host,
+				Target:        containerPath,
+				ReadOnly:      readOnly,
+				VolumeOptions: &mount.VolumeOptions{NoCopy: true},
+			})
+			continue
+		}
+
+		// Promoted for a propagation mode.
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   host,
+			Target:   containerPath,
+			ReadOnly: readOnly,
+			BindOptions: &mount.BindOptions{
+				Propagation: propagation,
+			},
+		})
+	}
+
+	return binds, mounts, nil
+}
+
+// isBindSource reports whether host looks like a bind-mount path (absolute
+// or relative to the current directory) rather than a named volume.
+func isBindSource(host string) bool {
+	return strings.HasPrefix(host, "/") || strings.HasPrefix(host, "./") || strings.HasPrefix(host, "../")
+}
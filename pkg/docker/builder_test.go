@@ -0,0 +1,187 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestParsePortSpecsContainerPortOnly(t *testing.T) {
+	exposed, bindings, err := parsePortSpecs([]string{"80"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := exposed[nat.Port("80/tcp")]; !ok {
+		t.Fatalf("expected 80/tcp to be exposed, got %v", exposed)
+	}
+	if len(bindings) != 0 {
+		t.Fatalf("expected no bindings for a container-only port, got %v", bindings)
+	}
+}
+
+func TestParsePortSpecsHostContainerPort(t *testing.T) {
+	exposed, bindings, err := parsePortSpecs([]string{"8080:80"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := exposed[nat.Port("80/tcp")]; !ok {
+		t.Fatalf("expected 80/tcp to be exposed, got %v", exposed)
+	}
+	got := bindings[nat.Port("80/tcp")]
+	if len(got) != 1 || got[0].HostPort != "8080" || got[0].HostIP != "0.0.0.0" {
+		t.Fatalf("unexpected binding for 8080:80: %+v", got)
+	}
+}
+
+func TestParsePortSpecsUDPProtocol(t *testing.T) {
+	exposed, bindings, err := parsePortSpecs([]string{"53:53/udp"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := exposed[nat.Port("53/udp")]; !ok {
+		t.Fatalf("expected 53/udp to be exposed, got %v", exposed)
+	}
+	got := bindings[nat.Port("53/udp")]
+	if len(got) != 1 || got[0].HostPort != "53" {
+		t.Fatalf("unexpected binding for 53:53/udp: %+v", got)
+	}
+}
+
+func TestParsePortSpecsHostIPBinding(t *testing.T) {
+	_, bindings, err := parsePortSpecs([]string{"127.0.0.1:8080:80"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := bindings[nat.Port("80/tcp")]
+	if len(got) != 1 || got[0].HostIP != "127.0.0.1" || got[0].HostPort != "8080" {
+		t.Fatalf("unexpected binding for 127.0.0.1:8080:80: %+v", got)
+	}
+}
+
+func TestParsePortSpecsRangeExpansion(t *testing.T) {
+	exposed, bindings, err := parsePortSpecs([]string{"8000-8002:8000-8002/udp"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, port := range []string{"8000", "8001", "8002"} {
+		p := nat.Port(port + "/udp")
+		if _, ok := exposed[p]; !ok {
+			t.Fatalf("expected %s to be exposed, got %v", p, exposed)
+		}
+		got := bindings[p]
+		if len(got) != 1 || got[0].HostPort != port {
+			t.Fatalf("unexpected binding for %s: %+v", p, got)
+		}
+	}
+}
+
+func TestParsePortSpecsMalformed(t *testing.T) {
+	cases := []string{
+		"not-a-port",
+		"8080:80/sctp-typo",
+		"8080:80:extra:colon",
+	}
+	for _, spec := range cases {
+		if _, _, err := parsePortSpecs([]string{spec}); err == nil {
+			t.Errorf("expected an error for malformed spec %q, got nil", spec)
+		}
+	}
+}
+
+func TestParseVolumeSpecsPlainBind(t *testing.T) {
+	binds, mounts, err := parseVolumeSpecs([]string{"/data:/app"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mounts) != 0 {
+		t.Fatalf("expected no mounts for a plain bind, got %v", mounts)
+	}
+	if len(binds) != 1 || binds[0] != "/data:/app" {
+		t.Fatalf("unexpected binds: %v", binds)
+	}
+}
+
+func TestParseVolumeSpecsSELinuxStaysOnBinds(t *testing.T) {
+	binds, mounts, err := parseVolumeSpecs([]string{"/data:/app:z,ro"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mounts) != 0 {
+		t.Fatalf("expected SELinux-only spec to stay on the Binds path, got mounts %v", mounts)
+	}
+	if len(binds) != 1 || binds[0] != "/data:/app:z,ro" {
+		t.Fatalf("unexpected binds: %v", binds)
+	}
+}
+
+func TestParseVolumeSpecsPropagation(t *testing.T) {
+	binds, mounts, err := parseVolumeSpecs([]string{"/data:/app:rslave"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(binds) != 0 {
+		t.Fatalf("expected no plain binds for a propagation spec, got %v", binds)
+	}
+	if len(mounts) != 1 {
+		t.Fatalf("expected exactly one mount, got %v", mounts)
+	}
+	m := mounts[0]
+	if m.Type != mount.TypeBind || m.Source != "/data" || m.Target != "/app" {
+		t.Fatalf("unexpected mount: %+v", m)
+	}
+	if m.BindOptions == nil || m.BindOptions.Propagation != mount.PropagationRSlave {
+		t.Fatalf("unexpected bind options: %+v", m.BindOptions)
+	}
+}
+
+func TestParseVolumeSpecsNoCopy(t *testing.T) {
+	binds, mounts, err := parseVolumeSpecs([]string{"mydata:/app:nocopy"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(binds) != 0 {
+		t.Fatalf("expected no plain binds for a nocopy spec, got %v", binds)
+	}
+	if len(mounts) != 1 {
+		t.Fatalf("expected exactly one mount, got %v", mounts)
+	}
+	m := mounts[0]
+	if m.Type != mount.TypeVolume || m.Source != "mydata" || m.Target != "/app" {
+		t.Fatalf("unexpected mount: %+v", m)
+	}
+	if m.VolumeOptions == nil || !m.VolumeOptions.NoCopy {
+		t.Fatalf("unexpected volume options: %+v", m.VolumeOptions)
+	}
+}
+
+func TestParseVolumeSpecsNoCopyRejectsBindSource(t *testing.T) {
+	if _, _, err := parseVolumeSpecs([]string{"/data:/app:nocopy"}); err == nil {
+		t.Fatalf("expected an error combining nocopy with a bind-mount source")
+	}
+}
+
+func TestParseVolumeSpecsSELinuxWithPropagationRejected(t *testing.T) {
+	if _, _, err := parseVolumeSpecs([]string{"/data:/app:Z,rslave"}); err == nil {
+		t.Fatalf("expected an error combining an SELinux option with a propagation mode")
+	}
+}
+
+func TestParseVolumeSpecsSELinuxWithNoCopyRejected(t *testing.T) {
+	if _, _, err := parseVolumeSpecs([]string{"mydata:/app:z,nocopy"}); err == nil {
+		t.Fatalf("expected an error combining an SELinux option with nocopy")
+	}
+}
+
+func TestParseVolumeSpecsMalformed(t *testing.T) {
+	cases := []string{
+		"onlyonepart",
+		"/data:/app:unknownopt",
+		"/data:/app:z,Z",
+	}
+	for _, spec := range cases {
+		if _, _, err := parseVolumeSpecs([]string{spec}); err == nil {
+			t.Errorf("expected an error for malformed spec %q, got nil", spec)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Package ipfs provides a thin client for pushing and pulling aig-built
+// images through an IPFS node, as a decentralized alternative to a private
+// registry.
+package ipfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	shell "github.com/ipfs/go-ipfs-api"
+)
+
+// DefaultGatewayURL is the IPFS HTTP gateway aig assumes when a layer
+// doesn't specify its own.
+const DefaultGatewayURL = "http://localhost:8080"
+
+// Client talks to a local or remote IPFS HTTP API.
+type Client struct {
+	sh *shell.Shell
+}
+
+// NewClient connects to the IPFS API at addr (e.g. "localhost:5001").
+func NewClient(addr string) *Client {
+	return &Client{sh: shell.NewShell(addr)}
+}
+
+// Add writes r to IPFS and returns its root CID.
+func (c *Client) Add(ctx context.Context, r io.Reader) (string, error) {
+	cid, err := c.sh.Add(r)
+	if err != nil {
+		return "", fmt.Errorf("ipfs add: %w", err)
+	}
+	return cid, nil
+}
+
+// Cat streams the content addressed by cid back from IPFS.
+func (c *Client) Cat(ctx context.Context, cid string) (io.ReadCloser, error) {
+	rc, err := c.sh.Cat(cid)
+	if err != nil {
+		return nil, fmt.Errorf("ipfs cat %s: %w", cid, err)
+	}
+	return rc, nil
+}
+
+// GatewayURL builds the public HTTP gateway path for cid, suitable for use
+// in a Dockerfile ADD instruction.
+func GatewayURL(base, cid string) string {
+	return fmt.Sprintf("%s/ipfs/%s", base, cid)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// ComposeFile is the schema of a top-level aig.yaml: it names a base image,
+// an ordered layer list, volumes, ports, and environment for a full `aig
+// run`, plus any layer-definition files to import so the layer names it
+// references can be resolved.
+type ComposeFile struct {
+	Import []string `yaml:"import" json:"import"`
+	Base   struct {
+		Image string `yaml:"image" json:"image"`
+	} `yaml:"base" json:"base"`
+	Layers  []string `yaml:"layers" json:"layers"`
+	Top     string   `yaml:"top" json:"top"`
+	Volumes []string `yaml:"volumes" json:"volumes"`
+	Ports   []string `yaml:"ports" json:"ports"`
+	Env     []string `yaml:"env" json:"env"`
+}
+
+// LoadCompose reads the aig.yaml (or .json) compose file at path and
+// registers any imported layer-definition files, so the Layers/Top names
+// it references are ready for layers.Get.
+func LoadCompose(path string) (*ComposeFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading compose file %q: %w", path, err)
+	}
+
+	if err := validateAgainst(composeSchema, path, data); err != nil {
+		return nil, fmt.Errorf("invalid compose file: %w", err)
+	}
+
+	var cf ComposeFile
+	if err := unmarshal(path, data, &cf); err != nil {
+		return nil, fmt.Errorf("parsing compose file %q: %w", path, err)
+	}
+
+	for _, imp := range cf.Import {
+		if err := LoadLayerDefs(resolveRelative(path, imp)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cf, nil
+}
@@ -0,0 +1,129 @@
+// Package config loads declarative layer definitions from YAML or JSON
+// files, so teams can add layers without editing layers/registry.go and
+// rebuilding the binary.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"aig/pkg/layers"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LayerDefs is the schema of a layer-definition file: a list of Include
+// paths to load first, plus one section per Layer implementation.
+type LayerDefs struct {
+	Include    []string        `yaml:"include" json:"include"`
+	Dependency []DependencyDef `yaml:"dependency" json:"dependency"`
+	Custom     []CustomDef     `yaml:"custom" json:"custom"`
+	Top        []TopDef        `yaml:"top" json:"top"`
+	CustomTop  []CustomTopDef  `yaml:"customTop" json:"customTop"`
+}
+
+// DependencyDef mirrors layers.DependencyLayer.
+type DependencyDef struct {
+	Name    string   `yaml:"name" json:"name"`
+	Pkgs    []string `yaml:"pkgs" json:"pkgs"`
+	Volumes []string `yaml:"volumes" json:"volumes"`
+	Ports   []string `yaml:"ports" json:"ports"`
+}
+
+// CustomDef mirrors layers.CustomLayer.
+type CustomDef struct {
+	Name     string   `yaml:"name" json:"name"`
+	Commands []string `yaml:"commands" json:"commands"`
+	Volumes  []string `yaml:"volumes" json:"volumes"`
+	Ports    []string `yaml:"ports" json:"ports"`
+}
+
+// TopDef mirrors layers.TopLayer.
+type TopDef struct {
+	Name       string   `yaml:"name" json:"name"`
+	BinaryURL  string   `yaml:"binaryUrl" json:"binaryUrl"`
+	BinaryPath string   `yaml:"binaryPath" json:"binaryPath"`
+	Volumes    []string `yaml:"volumes" json:"volumes"`
+	Ports      []string `yaml:"ports" json:"ports"`
+}
+
+// CustomTopDef mirrors layers.CustomTopLayer.
+type CustomTopDef struct {
+	Name     string   `yaml:"name" json:"name"`
+	Commands []string `yaml:"commands" json:"commands"`
+	Entry    []string `yaml:"entry" json:"entry"`
+	HashKey  string   `yaml:"hashKey" json:"hashKey"`
+}
+
+// LoadLayerDefs reads the YAML or JSON layer-definition file at path and
+// registers every layer it describes via layers.Register. Any `include`
+// entries are resolved relative to the including file and loaded first, so
+// a team can factor out a shared library of layer definitions; files are
+// only ever loaded once, so circular includes are safe.
+func LoadLayerDefs(path string) error {
+	return loadLayerDefs(path, make(map[string]bool))
+}
+
+func loadLayerDefs(path string, visited map[string]bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolving layer defs path %q: %w", path, err)
+	}
+	if visited[abs] {
+		return nil
+	}
+	visited[abs] = true
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return fmt.Errorf("reading layer defs %q: %w", path, err)
+	}
+
+	if err := validateAgainst(layerDefsSchema, abs, data); err != nil {
+		return fmt.Errorf("invalid layer defs: %w", err)
+	}
+
+	var defs LayerDefs
+	if err := unmarshal(abs, data, &defs); err != nil {
+		return fmt.Errorf("parsing layer defs %q: %w", path, err)
+	}
+
+	for _, inc := range defs.Include {
+		if err := loadLayerDefs(resolveRelative(abs, inc), visited); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range defs.Dependency {
+		layers.Register(&layers.DependencyLayer{Name: d.Name, Pkgs: d.Pkgs, Volumes: d.Volumes, Ports: d.Ports})
+	}
+	for _, d := range defs.Custom {
+		layers.Register(&layers.CustomLayer{Name: d.Name, Commands: d.Commands, Volumes: d.Volumes, Ports: d.Ports})
+	}
+	for _, d := range defs.Top {
+		layers.Register(&layers.TopLayer{Name: d.Name, BinaryURL: d.BinaryURL, BinaryPath: d.BinaryPath, Volumes: d.Volumes, Ports: d.Ports})
+	}
+	for _, d := range defs.CustomTop {
+		layers.Register(&layers.CustomTopLayer{Name: d.Name, Commands: d.Commands, Entry: d.Entry, HashKey: d.HashKey})
+	}
+
+	return nil
+}
+
+func resolveRelative(fromFile, target string) string {
+	if filepath.IsAbs(target) {
+		return target
+	}
+	return filepath.Join(filepath.Dir(fromFile), target)
+}
+
+// unmarshal decodes data as JSON when path ends in .json, and as YAML
+// otherwise (covering both .yaml and .yml).
+func unmarshal(path string, data []byte, out interface{}) error {
+	if filepath.Ext(path) == ".json" {
+		return json.Unmarshal(data, out)
+	}
+	return yaml.Unmarshal(data, out)
+}
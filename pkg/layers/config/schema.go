@@ -0,0 +1,45 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed schema/layerdefs.schema.json
+var layerDefsSchemaJSON []byte
+
+//go:embed schema/compose.schema.json
+var composeSchemaJSON []byte
+
+var (
+	layerDefsSchema = compileSchema("layerdefs.schema.json", layerDefsSchemaJSON)
+	composeSchema   = compileSchema("compose.schema.json", composeSchemaJSON)
+)
+
+// compileSchema compiles an embedded draft-07 schema, panicking if it's
+// malformed since that can only happen from a programming error in this
+// package, never from user input.
+func compileSchema(name string, data []byte) *jsonschema.Schema {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(name, bytes.NewReader(data)); err != nil {
+		panic(fmt.Sprintf("config: compiling embedded schema %q: %v", name, err))
+	}
+	return c.MustCompile(name)
+}
+
+// validateAgainst decodes path's raw bytes generically (honoring the same
+// JSON-vs-YAML rule as unmarshal) and checks them against schema, returning
+// a descriptive error on the first validation failure.
+func validateAgainst(schema *jsonschema.Schema, path string, data []byte) error {
+	var v interface{}
+	if err := unmarshal(path, data, &v); err != nil {
+		return err
+	}
+	if err := schema.Validate(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package layers
+
+import "aig/pkg/plugins/protocol"
+
+// PluginLayer satisfies Layer with the Commands/Volumes/Ports/Hash a
+// plugin reported for Name at resolve time. The spec and hash are fetched
+// once, up front, rather than re-queried per GetX() call: Builder.calculateTag
+// hashes GetHash()'s result straight into the image tag, so a value that
+// silently changed (or went empty on a later RPC failure) would corrupt tag
+// stability.
+type PluginLayer struct {
+	Name      string
+	Spec      protocol.LayerSpec
+	LayerHash string
+}
+
+func (l *PluginLayer) GetName() string       { return l.Name }
+func (l *PluginLayer) GetCommands() []string { return l.Spec.Commands }
+func (l *PluginLayer) GetHash() string       { return l.LayerHash }
+func (l *PluginLayer) GetVolumes() []string  { return l.Spec.Volumes }
+func (l *PluginLayer) GetPorts() []string    { return l.Spec.Ports }
@@ -0,0 +1,169 @@
+// Package plugins discovers and manages out-of-process layer plugins,
+// spawning plugin processes only when Resolve is asked for a layer.
+package plugins
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"aig/pkg/layers"
+	"aig/pkg/plugins/protocol"
+)
+
+// pluginDirEnv names the environment variable that overrides the default
+// plugin directory.
+const pluginDirEnv = "AIG_PLUGIN_PATH"
+
+// Dir returns the directory plugins are discovered from: AIG_PLUGIN_PATH if
+// set, otherwise ~/.aig/plugins.
+func Dir() (string, error) {
+	if dir := os.Getenv(pluginDirEnv); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolving plugin directory: %w", err)
+	}
+	return filepath.Join(home, ".aig", "plugins"), nil
+}
+
+// Info describes one discovered plugin binary.
+type Info struct {
+	Name string
+	Path string
+}
+
+// Discover lists every file (not subdirectory) in the plugin directory as a
+// plugin binary; it does not check the executable bit. A missing directory
+// is treated as no plugins installed.
+func Discover() ([]Info, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading plugin directory %q: %w", dir, err)
+	}
+
+	var found []Info
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		found = append(found, Info{Name: e.Name(), Path: filepath.Join(dir, e.Name())})
+	}
+	return found, nil
+}
+
+// Install copies the binary at srcPath into the plugin directory so it can
+// be discovered, making it executable.
+func Install(srcPath string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating plugin directory %q: %w", dir, err)
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("installing plugin %q: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	dstPath := filepath.Join(dir, filepath.Base(srcPath))
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755)
+	if err != nil {
+		return fmt.Errorf("installing plugin %q: %w", srcPath, err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("installing plugin %q: %w", srcPath, err)
+	}
+	return nil
+}
+
+// Remove deletes the named plugin binary from the plugin directory. name
+// must be a bare filename, not a path, so a plugin name can never be used
+// to delete files outside the plugin directory.
+func Remove(name string) error {
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return fmt.Errorf("removing plugin %q: name must be a bare filename", name)
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("removing plugin %q: %w", name, err)
+	}
+	return nil
+}
+
+// Resolve searches the installed plugins for one that advertises a layer
+// named name, starting and health-checking plugin processes only as needed
+// to find it, and closing every client it started along the way. The
+// matching layer's spec and hash are fetched once, up front, and cached on
+// the returned layers.PluginLayer; if either call fails, Resolve fails
+// loudly instead of registering a layer that would degrade silently later.
+func Resolve(name string) (*layers.PluginLayer, error) {
+	infos, err := Discover()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		client := protocol.NewClient(info.Path)
+
+		if err := client.Health(); err != nil {
+			fmt.Fprintf(os.Stderr, "plugin %q failed health check, skipping: %v\n", info.Name, err)
+			client.Close()
+			continue
+		}
+
+		descriptors, err := client.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "plugin %q failed to list layers, skipping: %v\n", info.Name, err)
+			client.Close()
+			continue
+		}
+
+		offers := false
+		for _, d := range descriptors {
+			if d.Name == name {
+				offers = true
+				break
+			}
+		}
+		if !offers {
+			client.Close()
+			continue
+		}
+
+		spec, err := client.Describe(name)
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("plugin %q: describing layer %q: %w", info.Name, name, err)
+		}
+		hash, err := client.Hash(name, nil)
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("plugin %q: hashing layer %q: %w", info.Name, name, err)
+		}
+		client.Close()
+
+		return &layers.PluginLayer{Name: name, Spec: *spec, LayerHash: hash}, nil
+	}
+
+	return nil, fmt.Errorf("no plugin provides layer %q", name)
+}
@@ -0,0 +1,167 @@
+// Package protocol implements the JSON-over-stdio wire format aig uses to
+// talk to out-of-process layer plugins: newline-delimited JSON requests
+// written to the plugin's stdin, newline-delimited JSON responses read back
+// from its stdout.
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// LayerDescriptor identifies one layer a plugin advertises via List.
+type LayerDescriptor struct {
+	Name string `json:"name"`
+}
+
+// LayerSpec is everything needed to compose a plugin-provided layer:
+// Dockerfile commands, volumes, and ports, returned by Describe.
+type LayerSpec struct {
+	Commands []string `json:"commands"`
+	Volumes  []string `json:"volumes"`
+	Ports    []string `json:"ports"`
+}
+
+// Request is sent to a plugin process for its List, Describe, Hash, and
+// Health methods.
+type Request struct {
+	Method string            `json:"method"`
+	Name   string            `json:"name,omitempty"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Response is what a plugin writes back for a Request; Error is set instead
+// of the other fields when the call failed.
+type Response struct {
+	Layers []LayerDescriptor `json:"layers,omitempty"`
+	Spec   *LayerSpec        `json:"spec,omitempty"`
+	Hash   string            `json:"hash,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// Client manages a single plugin process, lazily starting it on the first
+// call and reusing it for subsequent calls.
+type Client struct {
+	path string
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Scanner
+}
+
+// NewClient returns a Client for the plugin binary at path. The process
+// isn't started until the first call.
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+func (c *Client) ensureStartedLocked() error {
+	if c.cmd != nil {
+		return nil
+	}
+
+	cmd := exec.Command(c.path)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("starting plugin %s: %w", c.path, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("starting plugin %s: %w", c.path, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting plugin %s: %w", c.path, err)
+	}
+
+	c.cmd = cmd
+	c.in = stdin
+	c.out = bufio.NewScanner(stdout)
+	return nil
+}
+
+func (c *Client) call(req Request) (Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ensureStartedLocked(); err != nil {
+		return Response{}, err
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, err
+	}
+	if _, err := c.in.Write(append(data, '\n')); err != nil {
+		return Response{}, fmt.Errorf("calling plugin %s: %w", c.path, err)
+	}
+
+	if !c.out.Scan() {
+		if err := c.out.Err(); err != nil {
+			return Response{}, fmt.Errorf("calling plugin %s: %w", c.path, err)
+		}
+		return Response{}, fmt.Errorf("calling plugin %s: no response", c.path)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(c.out.Bytes(), &resp); err != nil {
+		return Response{}, fmt.Errorf("calling plugin %s: %w", c.path, err)
+	}
+	if resp.Error != "" {
+		return Response{}, fmt.Errorf("plugin %s: %s", c.path, resp.Error)
+	}
+	return resp, nil
+}
+
+// Health confirms the plugin process is up and responding.
+func (c *Client) Health() error {
+	_, err := c.call(Request{Method: "Health"})
+	return err
+}
+
+// List returns the layers the plugin advertises.
+func (c *Client) List() ([]LayerDescriptor, error) {
+	resp, err := c.call(Request{Method: "List"})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Layers, nil
+}
+
+// Describe returns the commands, volumes, and ports for the named layer.
+func (c *Client) Describe(name string) (*LayerSpec, error) {
+	resp, err := c.call(Request{Method: "Describe", Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Spec, nil
+}
+
+// Hash returns a deterministic hash for the named layer given params, so
+// Builder.calculateTag still produces reproducible tags.
+func (c *Client) Hash(name string, params map[string]string) (string, error) {
+	resp, err := c.call(Request{Method: "Hash", Name: name, Params: params})
+	if err != nil {
+		return "", err
+	}
+	return resp.Hash, nil
+}
+
+// Close shuts the plugin process down, if it was started.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmd == nil {
+		return nil
+	}
+	c.in.Close()
+	return c.cmd.Wait()
+}